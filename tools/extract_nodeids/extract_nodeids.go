@@ -0,0 +1,220 @@
+/*
+# This Source Code Form is subject to the terms of the Mozilla Public
+# License, v. 2.0. If a copy of the MPL was not distributed with this
+# file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+###
+### Author:
+### - Matthijs H. ten Berge (m.tenberge@awl.nl)
+###
+### This program was created for educational purposes and has been
+### contributed to the open62541 project by the author. All licensing
+### terms for this source is inherited by the terms and conditions
+### specified for by the open62541 project (see the projects readme
+### file for more information on the MPLv2 terms and restrictions).
+*/
+
+/*
+This stand-alone executable extracts the nodeids.csv that open62541's build
+system consumes out of a UANodeSet XML: one "<name>,<id>,<NodeClass>" line
+per UAObject, UAVariable, UAMethod, UAObjectType, UAVariableType,
+UAReferenceType, UADataType and UAView.
+
+It is a thin front-end on top of the nodeset package: the heavy lifting of
+streaming through the XML is done by nodeset.Parser, this program only
+supplies the per-class handlers and the CSV formatting/filtering rules.
+
+source and the output file may be "-" for stdin/stdout; source may also be
+gzip- or zip-compressed, see nodeset.OpenInput.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mtenberge/open62541/tools/nodeset"
+)
+
+var nodeClasses = []string{
+	nodeset.ClassObject,
+	nodeset.ClassVariable,
+	nodeset.ClassMethod,
+	nodeset.ClassObjectType,
+	nodeset.ClassVariableType,
+	nodeset.ClassReferenceType,
+	nodeset.ClassDataType,
+	nodeset.ClassView,
+}
+
+func printUsage() {
+	log.Println("Usage:")
+	log.Println("  extract_nodeids [flags] <source file> <output file>")
+	log.Println("    source file: the source filename containing a UANodeSet in XML format,")
+	log.Println("                 optionally gzip- or zip-compressed, or - to read from stdin")
+	log.Println("    output file: the CSV-file to which the NodeIds will be written, or - for stdout")
+	log.Println()
+	flag.PrintDefaults()
+}
+
+type uaNode struct {
+	NodeId      string `xml:",attr"`
+	BrowseName  string `xml:",attr"`
+	DisplayName string
+}
+
+var reNamespace *regexp.Regexp = regexp.MustCompile("^ns=[0-9]+;")
+var reNamespaceAndI *regexp.Regexp = regexp.MustCompile("^ns=[0-9]+;i=")
+var reNamespaceNumber *regexp.Regexp = regexp.MustCompile("^ns=([0-9]+);")
+
+// row is one output line, kept around in memory only when deterministic
+// sorting was requested.
+type row struct {
+	name      string
+	id        string
+	nodeClass string
+	namespace int
+}
+
+func namespaceOf(nodeId string) int {
+	if m := reNamespaceNumber.FindStringSubmatch(nodeId); m != nil {
+		ns, _ := strconv.Atoi(m[1])
+		return ns
+	}
+	return 0
+}
+
+func formatRow(node uaNode, nodeClass string) row {
+	name := node.DisplayName
+	if name == "" {
+		name = node.BrowseName
+	}
+	id := node.NodeId
+	namespace := namespaceOf(id)
+
+	// Remove the namespace part from the id: for integer IDs, remove
+	// everything except the number; for string/GUID/opaque IDs, keep the
+	// type prefix ("s=", "g=", "b=") but drop the "ns=" part.
+	id = reNamespaceAndI.ReplaceAllString(id, "")
+	id = reNamespace.ReplaceAllString(id, "")
+
+	name, id = nodeset.FormatCSVPair(name, id)
+
+	return row{name: name, id: id, nodeClass: nodeClass, namespace: namespace}
+}
+
+func parseNamespaceFilter(spec string) map[int]bool {
+	if spec == "" {
+		return nil
+	}
+	filter := make(map[int]bool)
+	for _, part := range strings.Split(spec, ",") {
+		ns, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			log.Fatalf("Invalid --ns value %q: %s\n", part, err.Error())
+		}
+		filter[ns] = true
+	}
+	return filter
+}
+
+func main() {
+	nsFlag := flag.String("ns", "", "comma-separated list of namespace indices to include (default: all)")
+	appendFlag := flag.Bool("append", false, "append to the output file instead of truncating it")
+	sortFlag := flag.Bool("sort", false, "sort output rows by namespace and NodeId for stable diffs across runs")
+	flag.Usage = printUsage
+	flag.Parse()
+
+	cmdlineArgs := flag.Args()
+	if len(cmdlineArgs) != 2 {
+		log.Println("Invalid number of command line arguments specified")
+		printUsage()
+		return
+	}
+
+	nsFilter := parseNamespaceFilter(*nsFlag)
+
+	log.Printf("Opening input file %s\n", cmdlineArgs[0])
+	infile, err := nodeset.OpenInput(cmdlineArgs[0])
+	if err != nil {
+		log.Printf("Error: %s\n", err.Error())
+		return
+	}
+	defer infile.Close()
+
+	outFlags := os.O_CREATE | os.O_WRONLY
+	if *appendFlag {
+		outFlags |= os.O_APPEND
+	} else {
+		outFlags |= os.O_TRUNC
+	}
+	log.Printf("Opening output file %s\n", cmdlineArgs[1])
+	outfile, err := nodeset.OpenOutput(cmdlineArgs[1], outFlags, 0666)
+	if err != nil {
+		log.Printf("Error: %s\n", err.Error())
+		return
+	}
+	defer outfile.Close()
+
+	var rows []row
+	writeRow := func(r row) {
+		if nsFilter != nil && !nsFilter[r.namespace] {
+			return
+		}
+		if *sortFlag {
+			rows = append(rows, r)
+			return
+		}
+		if _, err := fmt.Fprintf(outfile, "%s,%s,%s\n", r.name, r.id, r.nodeClass); err != nil {
+			log.Fatalf("Cannot write output file: %s", err.Error())
+		}
+	}
+
+	log.Printf("Searching for nodes\n")
+	parser := nodeset.NewParser()
+	for _, nodeClass := range nodeClasses {
+		nodeClass := nodeClass // capture for the closure
+		parser.Handle(nodeClass, func(ctx *nodeset.NodeContext) error {
+			var node uaNode
+			if err := ctx.Decode(&node); err != nil {
+				log.Fatalf("DecodeElement failed: %s\n", err.Error())
+			}
+			if node.NodeId == "" {
+				log.Printf("Found %s without NodeId, skipping\n", nodeClass)
+				return nil
+			}
+			if node.DisplayName == "" && node.BrowseName == "" {
+				log.Printf("Found %s (NodeId %s) without a name, skipping\n", nodeClass, node.NodeId)
+				return nil
+			}
+			writeRow(formatRow(node, nodeClass))
+			return nil
+		})
+	}
+
+	if err := parser.Walk(infile); err != nil {
+		log.Fatalf("XML decoder error: %s\n", err.Error())
+	}
+
+	if *sortFlag {
+		sort.Slice(rows, func(i, j int) bool {
+			if rows[i].namespace != rows[j].namespace {
+				return rows[i].namespace < rows[j].namespace
+			}
+			return rows[i].id < rows[j].id
+		})
+		for _, r := range rows {
+			if _, err := fmt.Fprintf(outfile, "%s,%s,%s\n", r.name, r.id, r.nodeClass); err != nil {
+				log.Fatalf("Cannot write output file: %s", err.Error())
+			}
+		}
+	}
+
+	log.Printf("Done!\n")
+}
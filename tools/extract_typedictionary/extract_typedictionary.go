@@ -17,7 +17,21 @@
 /*
 This stand-alone executable is used to extract the TypeDictionary section out of a UANodeSet XML.
 
-The XML is read and parsed in streaming mode, so performance with huge XML files should be ok.
+It is a thin front-end on top of the nodeset package: the heavy lifting of
+streaming through the XML is done by nodeset.Parser, this program only
+supplies the UAVariable handler that recognizes the requested NodeId and
+base64-decodes its ByteString value.
+
+With -bsd and/or -csv, a second stage parses the decoded ByteString as an
+OPC UA Binary Schema (opc:TypeDictionary) using the bsd package, and emits
+the normalized .bsd and/or the type-name-to-NodeId CSV that open62541's
+generate_datatypes.py expects. Resolving the NodeId of the UADataType
+matching each structured/enumerated type by name is done in the same pass
+as the UAVariable lookup, so source only ever needs to be read once and
+-csv works even when source is "-" (stdin).
+
+source and the output files may be "-" for stdin/stdout; source may also
+be gzip- or zip-compressed, see nodeset.OpenInput.
 */
 
 package main
@@ -25,91 +39,246 @@ package main
 import (
 	"bytes"
 	"encoding/base64"
-	"encoding/xml"
+	"flag"
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/mtenberge/open62541/tools/nodeset"
+	"github.com/mtenberge/open62541/tools/nodeset/bsd"
 )
 
 func printUsage() {
 	log.Println("Usage:")
-	log.Println("  extract_typedictionary <source> <node-id> <output file>")
-	log.Println("    source: the source filename containing a UANodeSet in XML format")
+	log.Println("  extract_typedictionary [flags] <source> <node-id> <output file>")
+	log.Println("    source: the source filename containing a UANodeSet in XML format,")
+	log.Println("            optionally gzip- or zip-compressed, or - to read from stdin")
 	log.Println("    node-id: the literal node-ID of the node containing the TypeDictionary (as it occurs in the XML), for example: ns=3;s=&quot;demoNodeName&quot;")
+	log.Println("    output file: the file to write the decoded TypeDictionary ByteString to, or - for stdout")
 	log.Println()
+	flag.PrintDefaults()
 }
 
-type UAVariable struct {
+type uaVariable struct {
 	DisplayName string
 	Value       struct {
 		ByteString []byte
 	}
 }
 
+type uaDataType struct {
+	NodeId      string `xml:",attr"`
+	DisplayName string
+}
+
+// dataTypeNodeId is a UADataType found while walking source, along with the
+// namespace index its NodeId carries, so it can be matched against the
+// namespace a bsd type name actually belongs to rather than by bare name
+// alone.
+type dataTypeNodeId struct {
+	nodeId    string
+	namespace int
+}
+
+var reNamespaceNumber = regexp.MustCompile("^ns=([0-9]+);")
+
+func namespaceOf(nodeId string) int {
+	if m := reNamespaceNumber.FindStringSubmatch(nodeId); m != nil {
+		ns, _ := strconv.Atoi(m[1])
+		return ns
+	}
+	return 0
+}
+
+// writeTypeNodeIdCsv resolves the NodeId of every structured and
+// enumerated type in td against nodeIds and writes "<name>,<id>" rows.
+//
+// A bare type name is resolved to the namespace it was declared in via
+// td.ResolveTypeName, and matched against the UADataType whose NodeId
+// carries that same namespace (via namespaceURIs, the NodeSet's
+// <NamespaceUris> table) - this is what disambiguates two UADataTypes
+// that happen to share a DisplayName across different namespaces. If the
+// source NodeSet had no <NamespaceUris> table to resolve against, this
+// falls back to matching by bare name and rejecting the type as ambiguous
+// if more than one NodeId turns up for it, rather than silently picking
+// one.
+func writeTypeNodeIdCsv(w io.Writer, td *bsd.TypeDictionary, nodeIds map[string][]dataTypeNodeId, namespaceURIs []string) error {
+	names := make([]string, 0, len(td.StructuredTypes)+len(td.EnumeratedTypes))
+	for _, st := range td.StructuredTypes {
+		names = append(names, st.Name)
+	}
+	for _, et := range td.EnumeratedTypes {
+		names = append(names, et.Name)
+	}
+
+	for _, name := range names {
+		candidates, ok := nodeIds[name]
+		if !ok {
+			log.Printf("No UADataType found for type %q, skipping\n", name)
+			continue
+		}
+
+		matches := candidates
+		if len(namespaceURIs) > 0 {
+			wantNamespace, _ := td.ResolveTypeName(name)
+			var byNamespace []dataTypeNodeId
+			for _, c := range candidates {
+				if c.namespace < len(namespaceURIs) && namespaceURIs[c.namespace] == wantNamespace {
+					byNamespace = append(byNamespace, c)
+				}
+			}
+			if len(byNamespace) > 0 {
+				matches = byNamespace
+			}
+		}
+
+		if len(matches) > 1 {
+			ids := make([]string, len(matches))
+			for i, c := range matches {
+				ids[i] = c.nodeId
+			}
+			return fmt.Errorf("DataType %q is ambiguous: found NodeIds %v", name, ids)
+		}
+		csvName, csvNodeId := nodeset.FormatCSVPair(name, matches[0].nodeId)
+		if _, err := fmt.Fprintf(w, "%s,%s\n", csvName, csvNodeId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func main() {
-	cmdlineArgs := os.Args[1:]
-	var err error
+	bsdFile := flag.String("bsd", "", "also write the decoded TypeDictionary to this normalized .bsd file")
+	csvFile := flag.String("csv", "", "also write a type-name-to-NodeId CSV to this file")
+	flag.Usage = printUsage
+	flag.Parse()
 
+	cmdlineArgs := flag.Args()
 	if len(cmdlineArgs) != 3 {
 		log.Println("Invalid number of command line arguments specified")
 		printUsage()
 		return
 	}
 
-	log.Printf("Opening input file %s\n", cmdlineArgs[0])
-	infile, err := os.Open(cmdlineArgs[0])
+	sourceFile, wantedNodeId, outputFile := cmdlineArgs[0], cmdlineArgs[1], cmdlineArgs[2]
+
+	log.Printf("Opening input file %s\n", sourceFile)
+	infile, err := nodeset.OpenInput(sourceFile)
 	if err != nil {
 		log.Printf("Error: %s\n", err.Error())
 		return
 	}
 	defer infile.Close()
 
-	decoder := xml.NewDecoder(infile)
+	log.Printf("Searching for the UAVariable with NodeID %s\n", wantedNodeId)
+
+	wantCsv := *csvFile != ""
 
-	// first search for the top-level UANodeSet:
-	log.Printf("Searching for the UAVariable with NodeID %s\n", cmdlineArgs[1])
-	for {
-		token, err := decoder.Token()
-		if token == nil {
-			log.Fatalf("End of file encountered before finding it\n")
+	var decoded bytes.Buffer
+	found := false
+	nodeIds := make(map[string][]dataTypeNodeId)
+	parser := nodeset.NewParser()
+	parser.Handle(nodeset.ClassVariable, func(ctx *nodeset.NodeContext) error {
+		if ctx.NodeId() != wantedNodeId {
+			return ctx.Skip()
 		}
-		if err != nil {
-			log.Fatalf("XML decoder error: %s\n", err.Error())
+
+		log.Printf("Found! Now unmarshalling this node\n")
+		var node uaVariable
+		if err := ctx.Decode(&node); err != nil {
+			log.Fatalf("DecodeElement failed: %s\n", err.Error())
+		}
+		log.Printf("DisplayName: %s\n", node.DisplayName)
+
+		base64Decoder := base64.NewDecoder(base64.StdEncoding, bytes.NewReader(node.Value.ByteString))
+		if _, err := io.Copy(&decoded, base64Decoder); err != nil {
+			log.Fatalf("Error while decoding ByteString: %s\n", err.Error())
 		}
-		switch se := token.(type) {
-		case xml.StartElement:
-			if se.Name.Local == "UAVariable" {
-				for _, attr := range se.Attr {
-					if attr.Name.Local == "NodeId" {
-						if attr.Value != cmdlineArgs[1] {
-							err = decoder.Skip()
-							break
-						} else {
-							// found!
-							log.Printf("Found! Now unmarshalling this node\n")
-							var node UAVariable
-							err = decoder.DecodeElement(&node, &se)
-							if err != nil {
-								log.Fatalf("DecodeElement failed: %s\n", err.Error())
-							}
-							log.Printf("DisplayName: %s\n", node.DisplayName)
-
-							log.Printf("Writing output file %s\n", cmdlineArgs[2])
-							outfile, err := os.Create(cmdlineArgs[2])
-							if err != nil {
-								log.Fatalf("Error while creating output file: %s\n", err.Error())
-							}
-							defer outfile.Close()
-
-							base64Decoder := base64.NewDecoder(base64.StdEncoding, bytes.NewReader(node.Value.ByteString))
-							io.Copy(outfile, base64Decoder)
-
-							return
-						}
+
+		found = true
+		if !wantCsv {
+			return nodeset.Stop
+		}
+		return nil
+	})
+	if wantCsv {
+		parser.Handle(nodeset.ClassDataType, func(ctx *nodeset.NodeContext) error {
+			var node uaDataType
+			if err := ctx.Decode(&node); err != nil {
+				return err
+			}
+			if node.NodeId != "" && node.DisplayName != "" {
+				candidates := nodeIds[node.DisplayName]
+				seen := false
+				for _, c := range candidates {
+					if c.nodeId == node.NodeId {
+						seen = true
+						break
 					}
 				}
+				if !seen {
+					nodeIds[node.DisplayName] = append(candidates, dataTypeNodeId{
+						nodeId:    node.NodeId,
+						namespace: namespaceOf(node.NodeId),
+					})
+				}
 			}
+			return nil
+		})
+	}
+
+	if err := parser.Walk(infile); err != nil {
+		log.Fatalf("XML decoder error: %s\n", err.Error())
+	}
+
+	if !found {
+		log.Fatalf("End of file encountered before finding it\n")
+	}
+
+	log.Printf("Writing output file %s\n", outputFile)
+	out, err := nodeset.OpenOutput(outputFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		log.Fatalf("Error while creating output file: %s\n", err.Error())
+	}
+	if _, err := out.Write(decoded.Bytes()); err != nil {
+		log.Fatalf("Error while writing output file: %s\n", err.Error())
+	}
+	out.Close()
+
+	if *bsdFile == "" && *csvFile == "" {
+		return
+	}
+
+	log.Printf("Parsing the decoded TypeDictionary\n")
+	td, err := bsd.Parse(bytes.NewReader(decoded.Bytes()))
+	if err != nil {
+		log.Fatalf("Could not parse TypeDictionary: %s\n", err.Error())
+	}
+
+	if *bsdFile != "" {
+		log.Printf("Writing normalized BSD file %s\n", *bsdFile)
+		outfile, err := nodeset.OpenOutput(*bsdFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+		if err != nil {
+			log.Fatalf("Error while creating %s: %s\n", *bsdFile, err.Error())
+		}
+		defer outfile.Close()
+		if err := td.WriteBSD(outfile); err != nil {
+			log.Fatalf("Error while writing %s: %s\n", *bsdFile, err.Error())
 		}
 	}
 
+	if *csvFile != "" {
+		log.Printf("Writing type-to-NodeId CSV %s\n", *csvFile)
+		outfile, err := nodeset.OpenOutput(*csvFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+		if err != nil {
+			log.Fatalf("Error while creating %s: %s\n", *csvFile, err.Error())
+		}
+		defer outfile.Close()
+		if err := writeTypeNodeIdCsv(outfile, td, nodeIds, parser.NamespaceURIs); err != nil {
+			log.Fatalf("Error while writing %s: %s\n", *csvFile, err.Error())
+		}
+	}
 }
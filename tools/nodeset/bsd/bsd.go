@@ -0,0 +1,256 @@
+/*
+# This Source Code Form is subject to the terms of the Mozilla Public
+# License, v. 2.0. If a copy of the MPL was not distributed with this
+# file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+###
+### Author:
+### - Matthijs H. ten Berge (m.tenberge@awl.nl)
+###
+### This program was created for educational purposes and has been
+### contributed to the open62541 project by the author. All licensing
+### terms for this source is inherited by the terms and conditions
+### specified for by the open62541 project (see the projects readme
+### file for more information on the MPLv2 terms and restrictions).
+*/
+
+/*
+Package bsd streams and resolves the OPC UA Binary Schema XML
+(opc:TypeDictionary) that a UAVariable of type TypeDictionaryType carries as
+its base64-encoded ByteString value.
+
+Like the nodeset package, Parse walks the document with encoding/xml's
+Decoder.Token instead of unmarshalling it in one go, so it scales to the
+large dictionaries some vendor NodeSets embed. The result is a resolved
+TypeDictionary that open62541's generate_datatypes.py can be fed, once
+written out as a normalized .bsd file alongside a type-name-to-NodeId CSV.
+*/
+package bsd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Field is a single member of a StructuredType.
+type Field struct {
+	Name        string `xml:"Name,attr"`
+	TypeName    string `xml:"TypeName,attr"`
+	LengthField string `xml:"LengthField,attr"`
+}
+
+// StructuredType mirrors an opc:StructuredType definition.
+type StructuredType struct {
+	Name     string  `xml:"Name,attr"`
+	BaseType string  `xml:"BaseType,attr"`
+	Field    []Field `xml:"Field"`
+}
+
+// EnumeratedValue is a single named value of an EnumeratedType.
+type EnumeratedValue struct {
+	Name  string `xml:"Name,attr"`
+	Value string `xml:"Value,attr"`
+}
+
+// EnumeratedType mirrors an opc:EnumeratedType definition.
+type EnumeratedType struct {
+	Name            string            `xml:"Name,attr"`
+	LengthInBits    string            `xml:"LengthInBits,attr"`
+	EnumeratedValue []EnumeratedValue `xml:"EnumeratedValue"`
+}
+
+// TypeDictionary is the resolved content of an opc:TypeDictionary document:
+// its own target namespace, the namespaces it imports, and the structured
+// and enumerated types it defines.
+type TypeDictionary struct {
+	TargetNamespace string
+	Imports         []string
+
+	StructuredTypes []StructuredType
+	EnumeratedTypes []EnumeratedType
+
+	prefixes map[string]string // xmlns prefix -> namespace URI
+}
+
+// Parse streams r and returns the TypeDictionary it describes. Type
+// references inside Field.TypeName and BaseType are left in their
+// original "prefix:Name" form; use ResolveTypeName to turn a reference
+// into a (namespace, name) pair.
+func Parse(r io.Reader) (*TypeDictionary, error) {
+	decoder := xml.NewDecoder(r)
+	td := &TypeDictionary{prefixes: make(map[string]string)}
+
+	for {
+		token, err := decoder.Token()
+		if token == nil {
+			if err == io.EOF {
+				return td, nil
+			}
+			return nil, err
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		se, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch se.Name.Local {
+		case "TypeDictionary":
+			for _, attr := range se.Attr {
+				switch {
+				case attr.Name.Space == "xmlns":
+					td.prefixes[attr.Name.Local] = attr.Value
+				case attr.Name.Local == "TargetNamespace":
+					td.TargetNamespace = attr.Value
+				}
+			}
+		case "Import":
+			for _, attr := range se.Attr {
+				if attr.Name.Local == "Namespace" {
+					td.Imports = append(td.Imports, attr.Value)
+				}
+			}
+		case "StructuredType":
+			var st StructuredType
+			if err := decoder.DecodeElement(&st, &se); err != nil {
+				return nil, err
+			}
+			td.StructuredTypes = append(td.StructuredTypes, st)
+		case "EnumeratedType":
+			var et EnumeratedType
+			if err := decoder.DecodeElement(&et, &se); err != nil {
+				return nil, err
+			}
+			td.EnumeratedTypes = append(td.EnumeratedTypes, et)
+		}
+	}
+}
+
+// ResolveTypeName splits a "prefix:Name" field/base type reference into
+// the namespace URI it was imported under and the bare type name. A
+// reference without a prefix belongs to the dictionary's own target
+// namespace.
+func (td *TypeDictionary) ResolveTypeName(ref string) (namespace, name string) {
+	prefix, local, hasPrefix := strings.Cut(ref, ":")
+	if !hasPrefix {
+		return td.TargetNamespace, ref
+	}
+	if ns, ok := td.prefixes[prefix]; ok {
+		return ns, local
+	}
+	// Unknown prefix: fall back to returning it verbatim so callers can
+	// still report a useful error instead of silently dropping it.
+	return prefix, local
+}
+
+// WriteBSD writes td back out as a normalized opc:TypeDictionary document:
+// structured and enumerated types in the order they were encountered,
+// fields written one per line with consistent attribute ordering. This is
+// the form open62541's generate_datatypes.py expects as input.
+func (td *TypeDictionary) WriteBSD(w io.Writer) error {
+	bw := &bsdWriter{w: w}
+
+	bw.printf("<opc:TypeDictionary TargetNamespace=%s xmlns:opc=\"http://opcfoundation.org/BinarySchema/\" xmlns:tns=%s",
+		attr(td.TargetNamespace), attr(td.TargetNamespace))
+	for _, prefix := range td.referencedPrefixes() {
+		ns, ok := td.prefixes[prefix]
+		if !ok {
+			// Unknown prefix: fall back to the prefix itself, matching
+			// ResolveTypeName's own fallback for a prefix Parse never saw
+			// declared, so the output is at least well-formed XML.
+			ns = prefix
+		}
+		bw.printf(" xmlns:%s=%s", prefix, attr(ns))
+	}
+	bw.printf(">\n")
+	for _, ns := range td.Imports {
+		bw.printf("  <opc:Import Namespace=%s/>\n", attr(ns))
+	}
+	for _, st := range td.StructuredTypes {
+		if st.BaseType != "" {
+			bw.printf("  <opc:StructuredType Name=%s BaseType=%s>\n", attr(st.Name), attr(st.BaseType))
+		} else {
+			bw.printf("  <opc:StructuredType Name=%s>\n", attr(st.Name))
+		}
+		for _, f := range st.Field {
+			if f.LengthField != "" {
+				bw.printf("    <opc:Field Name=%s TypeName=%s LengthField=%s/>\n", attr(f.Name), attr(f.TypeName), attr(f.LengthField))
+			} else {
+				bw.printf("    <opc:Field Name=%s TypeName=%s/>\n", attr(f.Name), attr(f.TypeName))
+			}
+		}
+		bw.printf("  </opc:StructuredType>\n")
+	}
+	for _, et := range td.EnumeratedTypes {
+		bw.printf("  <opc:EnumeratedType Name=%s LengthInBits=%s>\n", attr(et.Name), attr(et.LengthInBits))
+		for _, v := range et.EnumeratedValue {
+			bw.printf("    <opc:EnumeratedValue Name=%s Value=%s/>\n", attr(v.Name), attr(v.Value))
+		}
+		bw.printf("  </opc:EnumeratedType>\n")
+	}
+	bw.printf("</opc:TypeDictionary>\n")
+
+	return bw.err
+}
+
+// referencedPrefixes returns, sorted, every xmlns prefix other than the
+// always-declared "opc" and "tns" that a Field.TypeName or
+// StructuredType.BaseType actually references, so WriteBSD can declare
+// exactly the namespaces the output needs to be well-formed.
+func (td *TypeDictionary) referencedPrefixes() []string {
+	seen := make(map[string]bool)
+	add := func(ref string) {
+		prefix, _, hasPrefix := strings.Cut(ref, ":")
+		if !hasPrefix || prefix == "opc" || prefix == "tns" {
+			return
+		}
+		seen[prefix] = true
+	}
+	for _, st := range td.StructuredTypes {
+		add(st.BaseType)
+		for _, f := range st.Field {
+			add(f.TypeName)
+		}
+	}
+
+	prefixes := make([]string, 0, len(seen))
+	for prefix := range seen {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
+
+// attr XML-escapes s and wraps it in double quotes, ready to be spliced
+// into an attribute value. Unlike fmt's %q, this escapes the XML special
+// characters (&, <, >, ") instead of Go string-literal escaping them, so
+// the result is valid XML even when s itself contains a quote or an
+// ampersand.
+func attr(s string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('"')
+	xml.EscapeText(&buf, []byte(s))
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+// bsdWriter is a tiny helper so WriteBSD can read as a flat sequence of
+// printf calls instead of threading an error return through every line.
+type bsdWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (bw *bsdWriter) printf(format string, args ...interface{}) {
+	if bw.err != nil {
+		return
+	}
+	_, bw.err = fmt.Fprintf(bw.w, format, args...)
+}
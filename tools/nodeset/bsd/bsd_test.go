@@ -0,0 +1,158 @@
+/*
+# This Source Code Form is subject to the terms of the Mozilla Public
+# License, v. 2.0. If a copy of the MPL was not distributed with this
+# file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+###
+### Author:
+### - Matthijs H. ten Berge (m.tenberge@awl.nl)
+###
+### This program was created for educational purposes and has been
+### contributed to the open62541 project by the author. All licensing
+### terms for this source is inherited by the terms and conditions
+### specified for by the open62541 project (see the projects readme
+### file for more information on the MPLv2 terms and restrictions).
+*/
+
+package bsd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const sampleDictionary = `<opc:TypeDictionary TargetNamespace="http://example.org/demo/" xmlns:opc="http://opcfoundation.org/BinarySchema/" xmlns:tns="http://example.org/demo/" xmlns:ua="http://opcfoundation.org/UA/">
+  <opc:Import Namespace="http://opcfoundation.org/UA/"/>
+  <opc:StructuredType Name="Point">
+    <opc:Field Name="X" TypeName="opc:Double"/>
+    <opc:Field Name="Y" TypeName="opc:Double"/>
+  </opc:StructuredType>
+  <opc:StructuredType Name="PointArray" BaseType="ua:ExtensionObject">
+    <opc:Field Name="NoOfPoints" TypeName="opc:Int32"/>
+    <opc:Field Name="Points" TypeName="tns:Point" LengthField="NoOfPoints"/>
+  </opc:StructuredType>
+  <opc:EnumeratedType Name="Color" LengthInBits="32">
+    <opc:EnumeratedValue Name="Red" Value="0"/>
+    <opc:EnumeratedValue Name="Green" Value="1"/>
+  </opc:EnumeratedType>
+</opc:TypeDictionary>
+`
+
+func TestParse(t *testing.T) {
+	td, err := Parse(strings.NewReader(sampleDictionary))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if td.TargetNamespace != "http://example.org/demo/" {
+		t.Errorf("TargetNamespace = %q", td.TargetNamespace)
+	}
+	if len(td.Imports) != 1 || td.Imports[0] != "http://opcfoundation.org/UA/" {
+		t.Errorf("Imports = %v", td.Imports)
+	}
+	if len(td.StructuredTypes) != 2 || td.StructuredTypes[0].Name != "Point" || td.StructuredTypes[1].Name != "PointArray" {
+		t.Fatalf("StructuredTypes = %+v", td.StructuredTypes)
+	}
+	if td.StructuredTypes[1].BaseType != "ua:ExtensionObject" {
+		t.Errorf("PointArray.BaseType = %q", td.StructuredTypes[1].BaseType)
+	}
+	if len(td.EnumeratedTypes) != 1 || len(td.EnumeratedTypes[0].EnumeratedValue) != 2 {
+		t.Fatalf("EnumeratedTypes = %+v", td.EnumeratedTypes)
+	}
+}
+
+func TestResolveTypeName(t *testing.T) {
+	td, err := Parse(strings.NewReader(sampleDictionary))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	ns, name := td.ResolveTypeName("tns:Point")
+	if ns != "http://example.org/demo/" || name != "Point" {
+		t.Errorf("ResolveTypeName(tns:Point) = (%q, %q)", ns, name)
+	}
+
+	ns, name = td.ResolveTypeName("ua:ExtensionObject")
+	if ns != "http://opcfoundation.org/UA/" || name != "ExtensionObject" {
+		t.Errorf("ResolveTypeName(ua:ExtensionObject) = (%q, %q)", ns, name)
+	}
+
+	ns, name = td.ResolveTypeName("Int32")
+	if ns != td.TargetNamespace || name != "Int32" {
+		t.Errorf("ResolveTypeName(Int32) = (%q, %q)", ns, name)
+	}
+}
+
+// TestWriteBSDEscapesAttributes pins down that attribute values are escaped
+// as XML, not as Go string literals: a TargetNamespace containing "&" and a
+// StructuredType.Name containing a literal quote must both round-trip
+// through WriteBSD and back through Parse unchanged.
+func TestWriteBSDEscapesAttributes(t *testing.T) {
+	td := &TypeDictionary{
+		TargetNamespace: "http://example.org/a&b/",
+		StructuredTypes: []StructuredType{
+			{
+				Name: `Weird"Name`,
+				Field: []Field{
+					{Name: "X", TypeName: "opc:Int32"},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := td.WriteBSD(&buf); err != nil {
+		t.Fatalf("WriteBSD: %v", err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "a&b") {
+		t.Errorf("WriteBSD did not escape '&' in TargetNamespace: %s", out)
+	}
+	if strings.Contains(out, `\"`) {
+		t.Errorf("WriteBSD produced Go-style backslash escaping instead of XML escaping: %s", out)
+	}
+
+	roundTripped, err := Parse(&buf)
+	if err != nil {
+		t.Fatalf("round-trip Parse: %v\noutput was:\n%s", err, out)
+	}
+	if roundTripped.TargetNamespace != td.TargetNamespace {
+		t.Errorf("TargetNamespace round-trip = %q, want %q", roundTripped.TargetNamespace, td.TargetNamespace)
+	}
+	if len(roundTripped.StructuredTypes) != 1 || roundTripped.StructuredTypes[0].Name != td.StructuredTypes[0].Name {
+		t.Errorf("StructuredType.Name round-trip = %+v, want %q", roundTripped.StructuredTypes, td.StructuredTypes[0].Name)
+	}
+}
+
+// TestWriteBSDDeclaresReferencedNamespacePrefixes pins down that a
+// BaseType/TypeName referencing a namespace other than "opc"/"tns" (the
+// sample dictionary's PointArray.BaseType="ua:ExtensionObject") gets its
+// prefix declared on the root element, so the written .bsd file doesn't
+// reference an undeclared xmlns prefix.
+func TestWriteBSDDeclaresReferencedNamespacePrefixes(t *testing.T) {
+	td, err := Parse(strings.NewReader(sampleDictionary))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := td.WriteBSD(&buf); err != nil {
+		t.Fatalf("WriteBSD: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `xmlns:ua="http://opcfoundation.org/UA/"`) {
+		t.Errorf("WriteBSD did not declare xmlns:ua: %s", out)
+	}
+
+	roundTripped, err := Parse(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("round-trip Parse: %v\noutput was:\n%s", err, out)
+	}
+	ns, name := roundTripped.ResolveTypeName("ua:ExtensionObject")
+	if ns != "http://opcfoundation.org/UA/" || name != "ExtensionObject" {
+		t.Errorf("round-tripped ResolveTypeName(ua:ExtensionObject) = (%q, %q)", ns, name)
+	}
+}
@@ -0,0 +1,48 @@
+/*
+# This Source Code Form is subject to the terms of the Mozilla Public
+# License, v. 2.0. If a copy of the MPL was not distributed with this
+# file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+###
+### Author:
+### - Matthijs H. ten Berge (m.tenberge@awl.nl)
+###
+### This program was created for educational purposes and has been
+### contributed to the open62541 project by the author. All licensing
+### terms for this source is inherited by the terms and conditions
+### specified for by the open62541 project (see the projects readme
+### file for more information on the MPLv2 terms and restrictions).
+*/
+
+package nodeset
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var containsSpecialChars = regexp.MustCompile(`[^\w]`)
+
+// FormatCSVPair quotes a (name, id) pair for a "name,id" CSV row the way
+// every nodeids.csv/types.csv extractor in this repo does: if id contains
+// a literal quote, name is pre-quoted to match it, embedded quotes are
+// doubled, and either field is wrapped in quotes if it contains anything
+// other than word characters (which, notably, includes a comma).
+func FormatCSVPair(name, id string) (string, string) {
+	if strings.ContainsRune(id, '"') && !strings.ContainsRune(name, '"') {
+		name = fmt.Sprintf("\"%s\"", name)
+	}
+
+	name = strings.Replace(name, "\"", "\"\"", -1)
+	id = strings.Replace(id, "\"", "\"\"", -1)
+
+	if containsSpecialChars.MatchString(name) {
+		name = fmt.Sprintf("\"%s\"", name)
+	}
+	if containsSpecialChars.MatchString(id) {
+		id = fmt.Sprintf("\"%s\"", id)
+	}
+
+	return name, id
+}
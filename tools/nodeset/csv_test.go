@@ -0,0 +1,68 @@
+/*
+# This Source Code Form is subject to the terms of the Mozilla Public
+# License, v. 2.0. If a copy of the MPL was not distributed with this
+# file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+###
+### Author:
+### - Matthijs H. ten Berge (m.tenberge@awl.nl)
+###
+### This program was created for educational purposes and has been
+### contributed to the open62541 project by the author. All licensing
+### terms for this source is inherited by the terms and conditions
+### specified for by the open62541 project (see the projects readme
+### file for more information on the MPLv2 terms and restrictions).
+*/
+
+package nodeset
+
+import "testing"
+
+func TestFormatCSVPair(t *testing.T) {
+	tests := []struct {
+		name     string
+		inName   string
+		inId     string
+		wantName string
+		wantId   string
+	}{
+		{
+			name:     "plain values pass through unquoted",
+			inName:   "MyVariable",
+			inId:     "1001",
+			wantName: "MyVariable",
+			wantId:   "1001",
+		},
+		{
+			name:     "name with a space is quoted, id untouched",
+			inName:   "My Variable",
+			inId:     "1002",
+			wantName: `"My Variable"`,
+			wantId:   "1002",
+		},
+		{
+			name:     "quoted id pre-quotes the name and doubles embedded quotes",
+			inName:   "MyVariable",
+			inId:     `s="demo"`,
+			wantName: `"""MyVariable"""`,
+			wantId:   `"s=""demo"""`,
+		},
+		{
+			name:     "quote already embedded in the name is doubled and wrapped",
+			inName:   `Weird"Name`,
+			inId:     "2002",
+			wantName: `"Weird""Name"`,
+			wantId:   "2002",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotName, gotId := FormatCSVPair(tt.inName, tt.inId)
+			if gotName != tt.wantName || gotId != tt.wantId {
+				t.Errorf("FormatCSVPair(%q, %q) = (%q, %q), want (%q, %q)",
+					tt.inName, tt.inId, gotName, gotId, tt.wantName, tt.wantId)
+			}
+		})
+	}
+}
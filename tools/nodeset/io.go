@@ -0,0 +1,154 @@
+/*
+# This Source Code Form is subject to the terms of the Mozilla Public
+# License, v. 2.0. If a copy of the MPL was not distributed with this
+# file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+###
+### Author:
+### - Matthijs H. ten Berge (m.tenberge@awl.nl)
+###
+### This program was created for educational purposes and has been
+### contributed to the open62541 project by the author. All licensing
+### terms for this source is inherited by the terms and conditions
+### specified for by the open62541 project (see the projects readme
+### file for more information on the MPLv2 terms and restrictions).
+*/
+
+package nodeset
+
+import (
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+// OpenInput opens a NodeSet source for reading. path may be "-" to read
+// from stdin. The content is sniffed by magic bytes and transparently
+// unwrapped if it is gzip- or zip-compressed, so callers can hand the
+// result straight to a Parser without caring how it got there.
+//
+// Zip archives are only supported when path is a regular file, since
+// archive/zip needs to seek to the central directory at the end of the
+// stream; the first file in the archive is used. gzip streams are
+// supported from stdin as well as from a file.
+func OpenInput(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return sniffStream(bufio.NewReader(os.Stdin), nil)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(f)
+	magic, err := br.Peek(4)
+	if err != nil && err != io.EOF {
+		f.Close()
+		return nil, err
+	}
+
+	if hasPrefix(magic, zipMagic) {
+		f.Close() // zip needs its own ReaderAt, not this streaming handle
+		return openZipEntry(path)
+	}
+
+	return sniffStream(br, f)
+}
+
+// sniffStream wraps r in a gzip reader if it starts with the gzip magic
+// bytes, otherwise returns it unchanged. closer, if non-nil, is closed
+// alongside whatever reader sniffStream produces.
+func sniffStream(r *bufio.Reader, closer io.Closer) (io.ReadCloser, error) {
+	magic, err := r.Peek(2)
+	if err != nil && err != io.EOF {
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, err
+	}
+
+	if hasPrefix(magic, gzipMagic) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			if closer != nil {
+				closer.Close()
+			}
+			return nil, err
+		}
+		if closer != nil {
+			return &multiCloser{Reader: gz, closers: []io.Closer{gz, closer}}, nil
+		}
+		return gz, nil
+	}
+
+	if closer != nil {
+		return &multiCloser{Reader: r, closers: []io.Closer{closer}}, nil
+	}
+	return io.NopCloser(r), nil
+}
+
+func openZipEntry(path string) (io.ReadCloser, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(zr.File) == 0 {
+		zr.Close()
+		return nil, fmt.Errorf("zip archive %s contains no files", path)
+	}
+
+	rc, err := zr.File[0].Open()
+	if err != nil {
+		zr.Close()
+		return nil, err
+	}
+	return &multiCloser{Reader: rc, closers: []io.Closer{rc, zr}}, nil
+}
+
+func hasPrefix(b, prefix []byte) bool {
+	return len(b) >= len(prefix) && string(b[:len(prefix)]) == string(prefix)
+}
+
+// multiCloser adapts a Reader plus however many underlying Closers it took
+// to produce it (file handle, gzip reader, zip archive, ...) into a single
+// io.ReadCloser.
+type multiCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (mc *multiCloser) Close() error {
+	var firstErr error
+	for _, c := range mc.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// OpenOutput opens a destination for writing with the given flags and
+// permissions, as os.OpenFile would. path may be "-" to write to stdout,
+// in which case flags and perm are ignored and the returned Close is a
+// no-op so callers can always defer it unconditionally.
+func OpenOutput(path string, flags int, perm os.FileMode) (io.WriteCloser, error) {
+	if path == "-" {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	return os.OpenFile(path, flags, perm)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
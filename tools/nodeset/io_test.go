@@ -0,0 +1,170 @@
+/*
+# This Source Code Form is subject to the terms of the Mozilla Public
+# License, v. 2.0. If a copy of the MPL was not distributed with this
+# file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+###
+### Author:
+### - Matthijs H. ten Berge (m.tenberge@awl.nl)
+###
+### This program was created for educational purposes and has been
+### contributed to the open62541 project by the author. All licensing
+### terms for this source is inherited by the terms and conditions
+### specified for by the open62541 project (see the projects readme
+### file for more information on the MPLv2 terms and restrictions).
+*/
+
+package nodeset
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const wantContent = "<UANodeSet></UANodeSet>"
+
+func TestOpenInputPlainFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plain.xml")
+	if err := os.WriteFile(path, []byte(wantContent), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := OpenInput(path)
+	if err != nil {
+		t.Fatalf("OpenInput: %v", err)
+	}
+	defer r.Close()
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(got) != wantContent {
+		t.Errorf("got %q, want %q", got, wantContent)
+	}
+}
+
+func TestOpenInputGzipFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gzipped.xml.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	if _, err := gz.Write([]byte(wantContent)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := OpenInput(path)
+	if err != nil {
+		t.Fatalf("OpenInput: %v", err)
+	}
+	defer r.Close()
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(got) != wantContent {
+		t.Errorf("got %q, want %q", got, wantContent)
+	}
+}
+
+func TestOpenInputZipFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	entry, err := zw.Create("nodeset.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := entry.Write([]byte(wantContent)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := OpenInput(path)
+	if err != nil {
+		t.Fatalf("OpenInput: %v", err)
+	}
+	defer r.Close()
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(got) != wantContent {
+		t.Errorf("got %q, want %q", got, wantContent)
+	}
+}
+
+func TestSniffStreamGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(wantContent)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := sniffStream(bufio.NewReader(&buf), nil)
+	if err != nil {
+		t.Fatalf("sniffStream: %v", err)
+	}
+	defer r.Close()
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(got) != wantContent {
+		t.Errorf("got %q, want %q", got, wantContent)
+	}
+}
+
+func TestSniffStreamPlain(t *testing.T) {
+	r, err := sniffStream(bufio.NewReader(bytes.NewBufferString(wantContent)), nil)
+	if err != nil {
+		t.Fatalf("sniffStream: %v", err)
+	}
+	defer r.Close()
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(got) != wantContent {
+		t.Errorf("got %q, want %q", got, wantContent)
+	}
+}
+
+func TestOpenOutputStdout(t *testing.T) {
+	w, err := OpenOutput("-", os.O_WRONLY, 0666)
+	if err != nil {
+		t.Fatalf("OpenOutput: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("Close on stdout writer should be a no-op, got: %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+/*
+# This Source Code Form is subject to the terms of the Mozilla Public
+# License, v. 2.0. If a copy of the MPL was not distributed with this
+# file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+###
+### Author:
+### - Matthijs H. ten Berge (m.tenberge@awl.nl)
+###
+### This program was created for educational purposes and has been
+### contributed to the open62541 project by the author. All licensing
+### terms for this source is inherited by the terms and conditions
+### specified for by the open62541 project (see the projects readme
+### file for more information on the MPLv2 terms and restrictions).
+*/
+
+/*
+Package nodeset implements a streaming, SAX-style reader for UANodeSet XML
+documents (as exported by UaModeler, Siemens TIA Portal, and similar tools).
+
+Instead of unmarshalling the whole document into memory (which is not
+feasible for the multi-hundred-megabyte NodeSets some vendors ship), a
+Parser walks the document with encoding/xml's Decoder.Token and dispatches
+each top-level node element to a caller-registered handler. Handlers decide
+for themselves whether, and into what, a node is decoded - the parser never
+builds up state the caller didn't ask for.
+
+extract_typedictionary and extract_nodeids are both thin front-ends on top
+of this package; new extractors (BSD/OPC binary schema, alias tables,
+reference graphs, ...) can be built the same way without re-implementing
+XML iteration.
+*/
+package nodeset
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+// Stop can be returned by a Handler to make Walk return early without
+// treating it as an error, e.g. once the node the caller was looking for
+// has been found.
+var Stop = errors.New("nodeset: stop walking")
+
+// Well-known UANodeSet node class element names, for use with Parser.Handle.
+const (
+	ClassDataType      = "UADataType"
+	ClassVariable      = "UAVariable"
+	ClassObject        = "UAObject"
+	ClassMethod        = "UAMethod"
+	ClassReferenceType = "UAReferenceType"
+	ClassObjectType    = "UAObjectType"
+	ClassVariableType  = "UAVariableType"
+	ClassView          = "UAView"
+)
+
+// Reference mirrors a single <Reference> entry of a node's <References>
+// list. Handlers that care about the reference graph can embed References
+// into their own decode target.
+type Reference struct {
+	ReferenceType string `xml:",attr"`
+	IsForward     string `xml:"IsForward,attr"`
+	Target        string `xml:",chardata"`
+}
+
+// References mirrors a node's <References> element.
+type References struct {
+	Reference []Reference `xml:"Reference"`
+}
+
+// NodeContext is handed to a Handler for every matching start element. It
+// gives access to the element's attributes and, on demand, lets the
+// handler decode the element (or skip it) using the underlying decoder.
+type NodeContext struct {
+	Parser  *Parser
+	Start   xml.StartElement
+	decoder *xml.Decoder
+}
+
+// Attr returns the value of the named attribute on the node's start
+// element, or "" if it isn't present.
+func (ctx *NodeContext) Attr(name string) string {
+	for _, attr := range ctx.Start.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// NodeId returns the NodeId attribute of the current node.
+func (ctx *NodeContext) NodeId() string {
+	return ctx.Attr("NodeId")
+}
+
+// BrowseName returns the BrowseName attribute of the current node.
+func (ctx *NodeContext) BrowseName() string {
+	return ctx.Attr("BrowseName")
+}
+
+// Decode unmarshals the full current element, including any children,
+// into v. It is a thin wrapper around Decoder.DecodeElement and must be
+// called at most once per node.
+func (ctx *NodeContext) Decode(v interface{}) error {
+	return ctx.decoder.DecodeElement(v, &ctx.Start)
+}
+
+// Skip discards the current element without decoding it.
+func (ctx *NodeContext) Skip() error {
+	return ctx.decoder.Skip()
+}
+
+// Handler is called once for every start element whose local name was
+// registered with Parser.Handle. A handler must either Decode or Skip the
+// node before returning.
+type Handler func(ctx *NodeContext) error
+
+// Parser walks a UANodeSet XML document and dispatches nodes to
+// registered handlers.
+type Parser struct {
+	handlers map[string]Handler
+
+	// NamespaceURIs holds the <NamespaceUris><Uri>...</Uri></NamespaceUris>
+	// table of the NodeSet, indexed the same way as the "ns=" part of a
+	// NodeId (index 0 is always the OPC UA namespace and is not listed
+	// in the XML, so NamespaceURIs[0] is left empty).
+	NamespaceURIs []string
+}
+
+// NewParser returns an empty Parser. Register handlers with Handle before
+// calling Walk.
+func NewParser() *Parser {
+	return &Parser{handlers: make(map[string]Handler)}
+}
+
+// Handle registers fn to be called for every element named nodeClass
+// (e.g. nodeset.ClassDataType). Registering a handler for a class that
+// already has one replaces it.
+func (p *Parser) Handle(nodeClass string, fn Handler) {
+	p.handlers[nodeClass] = fn
+}
+
+type namespaceUris struct {
+	Uri []string `xml:"Uri"`
+}
+
+// Walk streams r token by token, dispatching every start element with a
+// registered handler and skipping everything else. It returns the first
+// error encountered, either from the XML decoder or from a handler.
+func (p *Parser) Walk(r io.Reader) error {
+	decoder := xml.NewDecoder(r)
+
+	for {
+		token, err := decoder.Token()
+		if token == nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		se, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		if se.Name.Local == "NamespaceUris" {
+			var uris namespaceUris
+			if err := decoder.DecodeElement(&uris, &se); err != nil {
+				return err
+			}
+			p.NamespaceURIs = append([]string{""}, uris.Uri...)
+			continue
+		}
+
+		handler, ok := p.handlers[se.Name.Local]
+		if !ok {
+			continue
+		}
+
+		ctx := &NodeContext{Parser: p, Start: se, decoder: decoder}
+		if err := handler(ctx); err != nil {
+			if err == Stop {
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,144 @@
+/*
+# This Source Code Form is subject to the terms of the Mozilla Public
+# License, v. 2.0. If a copy of the MPL was not distributed with this
+# file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+###
+### Author:
+### - Matthijs H. ten Berge (m.tenberge@awl.nl)
+###
+### This program was created for educational purposes and has been
+### contributed to the open62541 project by the author. All licensing
+### terms for this source is inherited by the terms and conditions
+### specified for by the open62541 project (see the projects readme
+### file for more information on the MPLv2 terms and restrictions).
+*/
+
+package nodeset
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleNodeSet = `<UANodeSet xmlns="http://opcfoundation.org/UA/2011/03/UANodeSet.xsd">
+  <NamespaceUris>
+    <Uri>http://example.org/demo/</Uri>
+  </NamespaceUris>
+  <UAVariable NodeId="ns=1;i=1001" BrowseName="Temperature">
+    <DisplayName>Temperature</DisplayName>
+  </UAVariable>
+  <UAObject NodeId="ns=1;i=1002" BrowseName="Sensor">
+    <DisplayName>Sensor</DisplayName>
+  </UAObject>
+  <UADataType NodeId="ns=1;i=1003" BrowseName="Reading">
+    <DisplayName>Reading</DisplayName>
+  </UADataType>
+</UANodeSet>
+`
+
+func TestWalkDispatchesRegisteredClassesAndSkipsOthers(t *testing.T) {
+	var variables, objects []string
+
+	p := NewParser()
+	p.Handle(ClassVariable, func(ctx *NodeContext) error {
+		variables = append(variables, ctx.NodeId())
+		return ctx.Skip()
+	})
+	p.Handle(ClassObject, func(ctx *NodeContext) error {
+		objects = append(objects, ctx.BrowseName())
+		return ctx.Skip()
+	})
+	// No handler registered for UADataType: Walk must silently pass over
+	// it rather than erroring or requiring every class to be handled.
+
+	if err := p.Walk(strings.NewReader(sampleNodeSet)); err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+
+	if len(variables) != 1 || variables[0] != "ns=1;i=1001" {
+		t.Errorf("variables = %v, want [ns=1;i=1001]", variables)
+	}
+	if len(objects) != 1 || objects[0] != "Sensor" {
+		t.Errorf("objects = %v, want [Sensor]", objects)
+	}
+}
+
+func TestWalkStopEndsEarlyWithoutError(t *testing.T) {
+	var seen []string
+
+	p := NewParser()
+	p.Handle(ClassVariable, func(ctx *NodeContext) error {
+		seen = append(seen, ctx.NodeId())
+		return Stop
+	})
+	p.Handle(ClassObject, func(ctx *NodeContext) error {
+		t.Error("handler for UAObject should not run after Stop was returned for an earlier node")
+		return ctx.Skip()
+	})
+
+	if err := p.Walk(strings.NewReader(sampleNodeSet)); err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	if len(seen) != 1 {
+		t.Errorf("seen = %v, want exactly one node visited before Stop", seen)
+	}
+}
+
+func TestWalkPropagatesHandlerError(t *testing.T) {
+	p := NewParser()
+	p.Handle(ClassVariable, func(ctx *NodeContext) error {
+		return errBoom
+	})
+
+	err := p.Walk(strings.NewReader(sampleNodeSet))
+	if err != errBoom {
+		t.Errorf("Walk error = %v, want errBoom", err)
+	}
+}
+
+func TestWalkPopulatesNamespaceURIs(t *testing.T) {
+	p := NewParser()
+	if err := p.Walk(strings.NewReader(sampleNodeSet)); err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+
+	want := []string{"", "http://example.org/demo/"}
+	if len(p.NamespaceURIs) != len(want) {
+		t.Fatalf("NamespaceURIs = %v, want %v", p.NamespaceURIs, want)
+	}
+	for i := range want {
+		if p.NamespaceURIs[i] != want[i] {
+			t.Errorf("NamespaceURIs[%d] = %q, want %q", i, p.NamespaceURIs[i], want[i])
+		}
+	}
+}
+
+func TestNodeContextDecode(t *testing.T) {
+	var displayName string
+
+	p := NewParser()
+	p.Handle(ClassDataType, func(ctx *NodeContext) error {
+		var node struct {
+			DisplayName string
+		}
+		if err := ctx.Decode(&node); err != nil {
+			return err
+		}
+		displayName = node.DisplayName
+		return nil
+	})
+
+	if err := p.Walk(strings.NewReader(sampleNodeSet)); err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	if displayName != "Reading" {
+		t.Errorf("displayName = %q, want %q", displayName, "Reading")
+	}
+}
+
+var errBoom = errTest("boom")
+
+type errTest string
+
+func (e errTest) Error() string { return string(e) }
@@ -0,0 +1,522 @@
+/*
+# This Source Code Form is subject to the terms of the Mozilla Public
+# License, v. 2.0. If a copy of the MPL was not distributed with this
+# file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+###
+### Author:
+### - Matthijs H. ten Berge (m.tenberge@awl.nl)
+###
+### This program was created for educational purposes and has been
+### contributed to the open62541 project by the author. All licensing
+### terms for this source is inherited by the terms and conditions
+### specified for by the open62541 project (see the projects readme
+### file for more information on the MPLv2 terms and restrictions).
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/mtenberge/open62541/tools/nodeset"
+	"github.com/mtenberge/open62541/tools/nodeset/bsd"
+)
+
+var nodeClasses = []string{
+	nodeset.ClassObject,
+	nodeset.ClassVariable,
+	nodeset.ClassMethod,
+	nodeset.ClassObjectType,
+	nodeset.ClassVariableType,
+	nodeset.ClassReferenceType,
+	nodeset.ClassDataType,
+	nodeset.ClassView,
+}
+
+var reNamespaceNumber = regexp.MustCompile("^ns=([0-9]+);")
+var reNamespacePrefix = regexp.MustCompile("^ns=[0-9]+;")
+
+type uaNode struct {
+	NodeId      string `xml:",attr"`
+	BrowseName  string `xml:",attr"`
+	DisplayName string
+}
+
+// row is one resolved, namespace-remapped NodeId, ready to be written out
+// as "<name>,ns=<namespace>;<id>,<NodeClass>".
+type row struct {
+	name      string
+	namespace int
+	id        string
+	nodeClass string
+}
+
+type compiledFilter struct {
+	class      string
+	browseName *regexp.Regexp
+}
+
+func compileFilters(filters []Filter) ([]compiledFilter, error) {
+	compiled := make([]compiledFilter, 0, len(filters))
+	for _, f := range filters {
+		cf := compiledFilter{class: f.Class}
+		if f.BrowseName != "" {
+			re, err := regexp.Compile(f.BrowseName)
+			if err != nil {
+				return nil, fmt.Errorf("invalid browse_name filter %q: %w", f.BrowseName, err)
+			}
+			cf.browseName = re
+		}
+		compiled = append(compiled, cf)
+	}
+	return compiled, nil
+}
+
+func (f compiledFilter) matches(class, browseName string) bool {
+	if f.class != "" && f.class != class {
+		return false
+	}
+	if f.browseName != nil && !f.browseName.MatchString(browseName) {
+		return false
+	}
+	return true
+}
+
+func keep(class, browseName string, include, exclude []compiledFilter) bool {
+	for _, f := range exclude {
+		if f.matches(class, browseName) {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, f := range include {
+		if f.matches(class, browseName) {
+			return true
+		}
+	}
+	return false
+}
+
+// orderNodeSets topologically sorts cfgs by DependsOn, so each NodeSet is
+// processed after everything it depends on.
+func orderNodeSets(cfgs []NodeSetConfig) ([]NodeSetConfig, error) {
+	byName := make(map[string]NodeSetConfig, len(cfgs))
+	for _, c := range cfgs {
+		byName[c.Name] = c
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(cfgs))
+	var ordered []NodeSetConfig
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("circular depends_on involving %q", name)
+		}
+		cfg, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("unknown depends_on target %q", name)
+		}
+		state[name] = visiting
+		for _, dep := range cfg.DependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		ordered = append(ordered, cfg)
+		return nil
+	}
+
+	for _, c := range cfgs {
+		if err := visit(c.Name); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+func namespaceOf(nodeId string) int {
+	if m := reNamespaceNumber.FindStringSubmatch(nodeId); m != nil {
+		ns, _ := strconv.Atoi(m[1])
+		return ns
+	}
+	return 0
+}
+
+// uaVariable additionally captures the ByteString a TypeDictionary
+// UAVariable carries, decoded in the same pass as every other node.
+type uaVariable struct {
+	uaNode
+	Value struct {
+		ByteString []byte
+	}
+}
+
+// processNodeSet streams cfg.Source exactly once: it applies cfg's
+// include/exclude filters and namespace remap to every node, appending the
+// result to rows, and - if cfg declares a type_dictionary - base64-decodes
+// that UAVariable's ByteString along the way instead of re-opening and
+// re-parsing the file for it. It also records, in namespaceIndexByURI,
+// which remapped namespace index each namespace URI this NodeSet declares
+// ends up at, so a bsd type's TargetNamespace can later be matched back to
+// the UADataType rows that actually live in that namespace.
+func processNodeSet(cfg NodeSetConfig, rows *[]row, namespaceIndexByURI map[string]int) (typeDictionary []byte, err error) {
+	include, err := compileFilters(cfg.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compileFilters(cfg.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	infile, err := nodeset.OpenInput(cfg.Source)
+	if err != nil {
+		return nil, err
+	}
+	defer infile.Close()
+
+	addRow := func(node uaNode, nodeClass string) {
+		if node.NodeId == "" {
+			return
+		}
+		name := node.DisplayName
+		if name == "" {
+			name = node.BrowseName
+		}
+		if name == "" {
+			return
+		}
+		if !keep(nodeClass, node.BrowseName, include, exclude) {
+			return
+		}
+
+		namespace := namespaceOf(node.NodeId)
+		if remapped, ok := cfg.NamespaceRemap[namespace]; ok {
+			namespace = remapped
+		}
+		id := reNamespacePrefix.ReplaceAllString(node.NodeId, "")
+
+		*rows = append(*rows, row{name: name, namespace: namespace, id: id, nodeClass: nodeClass})
+	}
+
+	var typeDictionaryBuf bytes.Buffer
+	foundTypeDictionary := false
+
+	parser := nodeset.NewParser()
+	for _, nodeClass := range nodeClasses {
+		nodeClass := nodeClass
+		if nodeClass == nodeset.ClassVariable && cfg.TypeDictionary != nil {
+			parser.Handle(nodeClass, func(ctx *nodeset.NodeContext) error {
+				// Only the one UAVariable matching TypeDictionary.NodeId
+				// needs its ByteString decoded; ctx.NodeId() is a cheap
+				// attribute scan, so check it before committing to the
+				// expensive decode every other UAVariable would otherwise
+				// also pay for.
+				if ctx.NodeId() != cfg.TypeDictionary.NodeId {
+					var node uaNode
+					if err := ctx.Decode(&node); err != nil {
+						return err
+					}
+					addRow(node, nodeClass)
+					return nil
+				}
+
+				var v uaVariable
+				if err := ctx.Decode(&v); err != nil {
+					return err
+				}
+				addRow(v.uaNode, nodeClass)
+				dec := base64.NewDecoder(base64.StdEncoding, bytes.NewReader(v.Value.ByteString))
+				if _, err := io.Copy(&typeDictionaryBuf, dec); err != nil {
+					return err
+				}
+				foundTypeDictionary = true
+				return nil
+			})
+			continue
+		}
+		parser.Handle(nodeClass, func(ctx *nodeset.NodeContext) error {
+			var node uaNode
+			if err := ctx.Decode(&node); err != nil {
+				return err
+			}
+			addRow(node, nodeClass)
+			return nil
+		})
+	}
+
+	if err := parser.Walk(infile); err != nil {
+		return nil, err
+	}
+
+	for i, uri := range parser.NamespaceURIs {
+		if uri == "" {
+			continue
+		}
+		namespace := i
+		if remapped, ok := cfg.NamespaceRemap[i]; ok {
+			namespace = remapped
+		}
+		namespaceIndexByURI[uri] = namespace
+	}
+
+	if cfg.TypeDictionary != nil && !foundTypeDictionary {
+		return nil, fmt.Errorf("NodeId %s not found in %s", cfg.TypeDictionary.NodeId, cfg.Source)
+	}
+	if !foundTypeDictionary {
+		return nil, nil
+	}
+	return typeDictionaryBuf.Bytes(), nil
+}
+
+func writeNodeidsCSV(path string, rows []row) error {
+	sorted := make([]row, len(rows))
+	copy(sorted, rows)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].namespace != sorted[j].namespace {
+			return sorted[i].namespace < sorted[j].namespace
+		}
+		if sorted[i].id != sorted[j].id {
+			return sorted[i].id < sorted[j].id
+		}
+		return sorted[i].name < sorted[j].name
+	})
+
+	out, err := nodeset.OpenOutput(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	for _, r := range sorted {
+		name, id := nodeset.FormatCSVPair(r.name, r.id)
+		if _, err := fmt.Fprintf(out, "%s,ns=%d;%s,%s\n", name, r.namespace, id, r.nodeClass); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeTypeDictionaries parses and concatenates every TypeDictionary blob
+// processNodeSet collected (one per NodeSet that declared a
+// type_dictionary) into a single TypeDictionary. It also returns, for
+// every structured/enumerated type name, the TargetNamespace URI of the
+// individual blob it came from - the merged TypeDictionary itself only
+// keeps the first blob's TargetNamespace, so that information would
+// otherwise be lost.
+func mergeTypeDictionaries(blobs [][]byte) (*bsd.TypeDictionary, map[string]string, error) {
+	var merged *bsd.TypeDictionary
+	namespaceByTypeName := make(map[string]string)
+	for _, blob := range blobs {
+		td, err := bsd.Parse(bytes.NewReader(blob))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, st := range td.StructuredTypes {
+			namespaceByTypeName[st.Name] = td.TargetNamespace
+		}
+		for _, et := range td.EnumeratedTypes {
+			namespaceByTypeName[et.Name] = td.TargetNamespace
+		}
+		if merged == nil {
+			merged = td
+			continue
+		}
+		merged.StructuredTypes = append(merged.StructuredTypes, td.StructuredTypes...)
+		merged.EnumeratedTypes = append(merged.EnumeratedTypes, td.EnumeratedTypes...)
+	}
+	return merged, namespaceByTypeName, nil
+}
+
+// writeTypesCSV resolves the NodeId of every structured and enumerated
+// type in td against rows and writes "<name>,ns=<namespace>;<id>" rows.
+//
+// A type name is resolved to the namespace it was declared in via
+// namespaceByTypeName, and matched against the UADataType rows whose
+// remapped namespace (via namespaceIndexByURI, built from every merged
+// NodeSet's NamespaceUris table) carries that same URI - this is what
+// disambiguates two UADataTypes that happen to share a DisplayName across
+// different namespaces. If that namespace can't be resolved to an index,
+// this falls back to matching by bare name and rejecting the type as
+// ambiguous if it occurs in more than one namespace, rather than silently
+// picking one.
+func writeTypesCSV(path string, td *bsd.TypeDictionary, rows []row, namespaceByTypeName map[string]string, namespaceIndexByURI map[string]int) error {
+	rowsByName := make(map[string][]row, len(rows))
+	for _, r := range rows {
+		if r.nodeClass != nodeset.ClassDataType {
+			continue
+		}
+		rowsByName[r.name] = append(rowsByName[r.name], r)
+	}
+
+	out, err := nodeset.OpenOutput(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	names := make([]string, 0, len(td.StructuredTypes)+len(td.EnumeratedTypes))
+	for _, st := range td.StructuredTypes {
+		names = append(names, st.Name)
+	}
+	for _, et := range td.EnumeratedTypes {
+		names = append(names, et.Name)
+	}
+
+	for _, typeName := range names {
+		candidates, ok := rowsByName[typeName]
+		if !ok {
+			log.Printf("No UADataType found for type %q, skipping\n", typeName)
+			continue
+		}
+
+		matches := candidates
+		if uri, ok := namespaceByTypeName[typeName]; ok {
+			if wantNamespace, ok := namespaceIndexByURI[uri]; ok {
+				var byNamespace []row
+				for _, r := range candidates {
+					if r.namespace == wantNamespace {
+						byNamespace = append(byNamespace, r)
+					}
+				}
+				if len(byNamespace) > 0 {
+					matches = byNamespace
+				}
+			}
+		}
+
+		if distinctNamespaces(rowNamespaces(matches)) > 1 {
+			return fmt.Errorf("DataType %q is ambiguous: found in namespaces %v across the merged NodeSets", typeName, rowNamespaces(matches))
+		}
+		r := matches[0]
+		name, id := nodeset.FormatCSVPair(typeName, r.id)
+		if _, err := fmt.Fprintf(out, "%s,ns=%d;%s\n", name, r.namespace, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rowNamespaces returns the namespace index of every row in rows.
+func rowNamespaces(rows []row) []int {
+	ns := make([]int, len(rows))
+	for i, r := range rows {
+		ns[i] = r.namespace
+	}
+	return ns
+}
+
+// distinctNamespaces counts how many distinct namespace indices occur in ns.
+func distinctNamespaces(ns []int) int {
+	seen := make(map[int]bool, len(ns))
+	for _, n := range ns {
+		seen[n] = true
+	}
+	return len(seen)
+}
+
+func generateHeaders(h *HeadersConfig, bsdPath, typesCSVPath string) error {
+	args := []string{h.Generator, "--bsd", bsdPath}
+	if typesCSVPath != "" {
+		args = append(args, "--type-csv", typesCSVPath)
+	}
+	args = append(args, "--outfile", h.OutFile)
+
+	cmd := exec.Command("python3", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Build runs every configured NodeSet through a single streaming pass and
+// writes out the configured artifacts.
+func Build(cfg *Config) error {
+	ordered, err := orderNodeSets(cfg.NodeSets)
+	if err != nil {
+		return err
+	}
+
+	var rows []row
+	var typeDictionaries [][]byte
+	namespaceIndexByURI := make(map[string]int)
+	for _, nsCfg := range ordered {
+		log.Printf("Processing NodeSet %q (%s)\n", nsCfg.Name, nsCfg.Source)
+		blob, err := processNodeSet(nsCfg, &rows, namespaceIndexByURI)
+		if err != nil {
+			return fmt.Errorf("%s: %w", nsCfg.Name, err)
+		}
+		if blob != nil {
+			typeDictionaries = append(typeDictionaries, blob)
+		}
+	}
+
+	if cfg.Outputs.NodeidsCSV != "" {
+		log.Printf("Writing %s\n", cfg.Outputs.NodeidsCSV)
+		if err := writeNodeidsCSV(cfg.Outputs.NodeidsCSV, rows); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Outputs.BSD != "" || cfg.Outputs.TypesCSV != "" || cfg.Outputs.Headers != nil {
+		td, namespaceByTypeName, err := mergeTypeDictionaries(typeDictionaries)
+		if err != nil {
+			return err
+		}
+		if td == nil {
+			return fmt.Errorf("no nodeset in the config declares a type_dictionary, but a BSD/types/headers output was requested")
+		}
+
+		if cfg.Outputs.BSD != "" {
+			log.Printf("Writing %s\n", cfg.Outputs.BSD)
+			out, err := nodeset.OpenOutput(cfg.Outputs.BSD, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+			if err != nil {
+				return err
+			}
+			defer out.Close()
+			if err := td.WriteBSD(out); err != nil {
+				return err
+			}
+		}
+
+		if cfg.Outputs.TypesCSV != "" {
+			log.Printf("Writing %s\n", cfg.Outputs.TypesCSV)
+			if err := writeTypesCSV(cfg.Outputs.TypesCSV, td, rows, namespaceByTypeName, namespaceIndexByURI); err != nil {
+				return err
+			}
+		}
+
+		if h := cfg.Outputs.Headers; h != nil {
+			log.Printf("Generating %s\n", h.OutFile)
+			if cfg.Outputs.BSD == "" {
+				return fmt.Errorf("outputs.headers requires outputs.bsd to also be set")
+			}
+			if err := generateHeaders(h, cfg.Outputs.BSD, cfg.Outputs.TypesCSV); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+/*
+# This Source Code Form is subject to the terms of the Mozilla Public
+# License, v. 2.0. If a copy of the MPL was not distributed with this
+# file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+###
+### Author:
+### - Matthijs H. ten Berge (m.tenberge@awl.nl)
+###
+### This program was created for educational purposes and has been
+### contributed to the open62541 project by the author. All licensing
+### terms for this source is inherited by the terms and conditions
+### specified for by the open62541 project (see the projects readme
+### file for more information on the MPLv2 terms and restrictions).
+*/
+
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mtenberge/open62541/tools/nodeset/bsd"
+)
+
+func TestOrderNodeSets(t *testing.T) {
+	cfgs := []NodeSetConfig{
+		{Name: "di", DependsOn: []string{"core"}},
+		{Name: "core"},
+		{Name: "plc", DependsOn: []string{"di", "core"}},
+	}
+
+	ordered, err := orderNodeSets(cfgs)
+	if err != nil {
+		t.Fatalf("orderNodeSets: %v", err)
+	}
+
+	pos := make(map[string]int, len(ordered))
+	for i, c := range ordered {
+		pos[c.Name] = i
+	}
+	if pos["core"] > pos["di"] {
+		t.Errorf("core must be ordered before di, got order %v", names(ordered))
+	}
+	if pos["di"] > pos["plc"] {
+		t.Errorf("di must be ordered before plc, got order %v", names(ordered))
+	}
+}
+
+func TestOrderNodeSetsDetectsCycle(t *testing.T) {
+	cfgs := []NodeSetConfig{
+		{Name: "a", DependsOn: []string{"b"}},
+		{Name: "b", DependsOn: []string{"a"}},
+	}
+
+	_, err := orderNodeSets(cfgs)
+	if err == nil {
+		t.Fatal("orderNodeSets: expected an error for a circular depends_on, got nil")
+	}
+	if !strings.Contains(err.Error(), "circular depends_on") {
+		t.Errorf("orderNodeSets error = %q, want it to mention circular depends_on", err.Error())
+	}
+}
+
+func TestOrderNodeSetsUnknownDependency(t *testing.T) {
+	cfgs := []NodeSetConfig{
+		{Name: "a", DependsOn: []string{"missing"}},
+	}
+
+	_, err := orderNodeSets(cfgs)
+	if err == nil {
+		t.Fatal("orderNodeSets: expected an error for an unknown depends_on target, got nil")
+	}
+}
+
+func names(cfgs []NodeSetConfig) []string {
+	out := make([]string, len(cfgs))
+	for i, c := range cfgs {
+		out[i] = c.Name
+	}
+	return out
+}
+
+func TestKeep(t *testing.T) {
+	include, err := compileFilters([]Filter{{Class: "UAVariable"}})
+	if err != nil {
+		t.Fatalf("compileFilters(include): %v", err)
+	}
+	exclude, err := compileFilters([]Filter{{BrowseName: "^Internal"}})
+	if err != nil {
+		t.Fatalf("compileFilters(exclude): %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		class      string
+		browseName string
+		want       bool
+	}{
+		{"matches include, no exclude hit", "UAVariable", "Temperature", true},
+		{"wrong class is dropped", "UAObject", "Temperature", false},
+		{"excluded browse name wins even if class matches", "UAVariable", "InternalState", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keep(tt.class, tt.browseName, include, exclude); got != tt.want {
+				t.Errorf("keep(%q, %q) = %v, want %v", tt.class, tt.browseName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeepNoIncludeFiltersKeepsEverythingNotExcluded(t *testing.T) {
+	exclude, err := compileFilters([]Filter{{Class: "UAMethod"}})
+	if err != nil {
+		t.Fatalf("compileFilters(exclude): %v", err)
+	}
+
+	if !keep("UAVariable", "Anything", nil, exclude) {
+		t.Error("keep: expected true when there are no include filters and no exclude match")
+	}
+	if keep("UAMethod", "Anything", nil, exclude) {
+		t.Error("keep: expected false when the exclude filter matches")
+	}
+}
+
+func TestWriteTypesCSVResolvesByNamespace(t *testing.T) {
+	td := &bsd.TypeDictionary{
+		TargetNamespace: "http://example.org/custom/",
+		StructuredTypes: []bsd.StructuredType{{Name: "Reading"}},
+	}
+	rows := []row{
+		{name: "Reading", namespace: 1, id: "i=100", nodeClass: "UADataType"},
+		{name: "Reading", namespace: 2, id: "i=200", nodeClass: "UADataType"},
+	}
+	namespaceByTypeName := map[string]string{"Reading": "http://example.org/custom/"}
+	namespaceIndexByURI := map[string]int{
+		"http://example.org/core/":   1,
+		"http://example.org/custom/": 2,
+	}
+
+	path := t.TempDir() + "/types.csv"
+	if err := writeTypesCSV(path, td, rows, namespaceByTypeName, namespaceIndexByURI); err != nil {
+		t.Fatalf("writeTypesCSV: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	want := "Reading,ns=2;\"i=200\"\n"
+	if string(got) != want {
+		t.Errorf("types.csv = %q, want %q", string(got), want)
+	}
+}
+
+func TestWriteTypesCSVRejectsAmbiguousWithoutNamespaceData(t *testing.T) {
+	td := &bsd.TypeDictionary{
+		StructuredTypes: []bsd.StructuredType{{Name: "Reading"}},
+	}
+	rows := []row{
+		{name: "Reading", namespace: 1, id: "i=100", nodeClass: "UADataType"},
+		{name: "Reading", namespace: 2, id: "i=200", nodeClass: "UADataType"},
+	}
+
+	path := t.TempDir() + "/types.csv"
+	err := writeTypesCSV(path, td, rows, nil, nil)
+	if err == nil {
+		t.Fatal("writeTypesCSV: expected an error for an ambiguous DataType name, got nil")
+	}
+	if !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("writeTypesCSV error = %q, want it to mention ambiguous", err.Error())
+	}
+}
+
+func TestDistinctNamespaces(t *testing.T) {
+	if got := distinctNamespaces([]int{1, 1, 1}); got != 1 {
+		t.Errorf("distinctNamespaces([1,1,1]) = %d, want 1", got)
+	}
+	if got := distinctNamespaces([]int{1, 2, 1}); got != 2 {
+		t.Errorf("distinctNamespaces([1,2,1]) = %d, want 2", got)
+	}
+	if got := distinctNamespaces(nil); got != 0 {
+		t.Errorf("distinctNamespaces(nil) = %d, want 0", got)
+	}
+}
@@ -0,0 +1,90 @@
+/*
+# This Source Code Form is subject to the terms of the Mozilla Public
+# License, v. 2.0. If a copy of the MPL was not distributed with this
+# file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+###
+### Author:
+### - Matthijs H. ten Berge (m.tenberge@awl.nl)
+###
+### This program was created for educational purposes and has been
+### contributed to the open62541 project by the author. All licensing
+### terms for this source is inherited by the terms and conditions
+### specified for by the open62541 project (see the projects readme
+### file for more information on the MPLv2 terms and restrictions).
+*/
+
+package main
+
+import (
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Filter matches nodes by NodeClass and/or a BrowseName regular
+// expression. An empty Class matches every class; an empty BrowseName
+// matches every name.
+type Filter struct {
+	Class      string `yaml:"class"`
+	BrowseName string `yaml:"browse_name"`
+}
+
+// TypeDictionaryConfig points at the UAVariable a NodeSet's embedded OPC
+// Binary Schema should be decompiled from.
+type TypeDictionaryConfig struct {
+	NodeId string `yaml:"node_id"`
+}
+
+// NodeSetConfig describes a single input NodeSet and how it should be
+// processed relative to the others.
+type NodeSetConfig struct {
+	Name      string   `yaml:"name"`
+	Source    string   `yaml:"source"`
+	DependsOn []string `yaml:"depends_on"`
+
+	// NamespaceRemap maps this file's own namespace indices onto the
+	// indices they should carry in the combined output, so NodeSets that
+	// were authored independently can be merged without index clashes.
+	NamespaceRemap map[int]int `yaml:"namespace_remap"`
+
+	Include []Filter `yaml:"include"`
+	Exclude []Filter `yaml:"exclude"`
+
+	TypeDictionary *TypeDictionaryConfig `yaml:"type_dictionary"`
+}
+
+// HeadersConfig describes how to turn the generated .bsd/.csv pair into C
+// code, by shelling out to open62541's existing generate_datatypes.py.
+type HeadersConfig struct {
+	Generator string `yaml:"generator"`
+	OutFile   string `yaml:"out_file"`
+}
+
+// Outputs lists the artifacts a build should produce. Any field left
+// empty is simply not generated.
+type Outputs struct {
+	NodeidsCSV string         `yaml:"nodeids_csv"`
+	BSD        string         `yaml:"bsd"`
+	TypesCSV   string         `yaml:"types_csv"`
+	Headers    *HeadersConfig `yaml:"headers"`
+}
+
+// Config is the top-level nodesetc build configuration.
+type Config struct {
+	NodeSets []NodeSetConfig `yaml:"nodesets"`
+	Outputs  Outputs         `yaml:"outputs"`
+}
+
+// LoadConfig reads and parses a nodesetc YAML configuration file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
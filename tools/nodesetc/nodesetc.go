@@ -0,0 +1,61 @@
+/*
+# This Source Code Form is subject to the terms of the Mozilla Public
+# License, v. 2.0. If a copy of the MPL was not distributed with this
+# file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+###
+### Author:
+### - Matthijs H. ten Berge (m.tenberge@awl.nl)
+###
+### This program was created for educational purposes and has been
+### contributed to the open62541 project by the author. All licensing
+### terms for this source is inherited by the terms and conditions
+### specified for by the open62541 project (see the projects readme
+### file for more information on the MPLv2 terms and restrictions).
+*/
+
+/*
+nodesetc is a YAML-driven front-end on top of the nodeset and bsd
+packages. Where extract_nodeids and extract_typedictionary each take a
+single NodeSet on the command line, nodesetc reads a config describing
+any number of input NodeSets, their dependency order, namespace
+remappings and per-file NodeClass/BrowseName filters, and produces a
+reproducible set of outputs from a single streaming pass per input.
+
+Usage:
+
+	nodesetc build config.yaml
+
+See config.go for the YAML schema.
+*/
+package main
+
+import (
+	"log"
+	"os"
+)
+
+func printUsage() {
+	log.Println("Usage:")
+	log.Println("  nodesetc build <config.yaml>")
+	log.Println()
+}
+
+func main() {
+	args := os.Args[1:]
+	if len(args) != 2 || args[0] != "build" {
+		printUsage()
+		os.Exit(1)
+	}
+
+	cfg, err := LoadConfig(args[1])
+	if err != nil {
+		log.Fatalf("Could not load config %s: %s\n", args[1], err.Error())
+	}
+
+	if err := Build(cfg); err != nil {
+		log.Fatalf("Build failed: %s\n", err.Error())
+	}
+
+	log.Println("Done!")
+}